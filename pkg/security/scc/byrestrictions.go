@@ -1,92 +1,239 @@
 package scc
 
 import (
+	"math"
+
 	kapi "k8s.io/kubernetes/pkg/api"
 	securityapi "github.com/openshift/origin/pkg/security/apis/security"
 )
 
-// ByRestrictions is a helper to sort SCCs in order of most restrictive to least restrictive.
-type ByRestrictions []*securityapi.SecurityContextConstraints
+// ByRestrictions is a helper to sort SCCs by priority and then from most restrictive to
+// least restrictive (see Less), using Weights to decide how much each restriction-relevant
+// field counts.  A nil Weights falls back to DefaultWeightTable.
+type ByRestrictions struct {
+	SCCs    []*securityapi.SecurityContextConstraints
+	Weights *WeightTable
+}
+
+// NewByRestrictions returns a ByRestrictions that ranks sccs using weights. A nil weights
+// uses DefaultWeightTable.
+func NewByRestrictions(sccs []*securityapi.SecurityContextConstraints, weights *WeightTable) ByRestrictions {
+	return ByRestrictions{SCCs: sccs, Weights: weights}
+}
 
 func (s ByRestrictions) Len() int {
-	return len(s)
+	return len(s.SCCs)
 }
-func (s ByRestrictions) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s ByRestrictions) Swap(i, j int) { s.SCCs[i], s.SCCs[j] = s.SCCs[j], s.SCCs[i] }
+
+// Less orders SCCs with the admission plugin's preferred candidate first.
+// SCCs are compared, in order, by:
+//  1. Priority: a higher priority is preferred over a lower one; an SCC
+//     with no priority set is treated as the lowest possible priority.
+//  2. pointValue: among SCCs of equal priority, the more restrictive SCC
+//     (lower pointValue) is preferred.
+//  3. Name: among SCCs of equal priority and pointValue, ordering by name
+//     keeps the sort deterministic.
 func (s ByRestrictions) Less(i, j int) bool {
-	return pointValue(s[i]) < pointValue(s[j])
+	iPriority := priorityValue(s.SCCs[i])
+	jPriority := priorityValue(s.SCCs[j])
+	if iPriority != jPriority {
+		return iPriority > jPriority
+	}
+
+	iPoints := pointValue(s.SCCs[i], s.Weights)
+	jPoints := pointValue(s.SCCs[j], s.Weights)
+	if iPoints != jPoints {
+		return iPoints < jPoints
+	}
+
+	return s.SCCs[i].Name < s.SCCs[j].Name
+}
+
+// priorityValue returns the SCC's priority, treating a nil Priority as the
+// lowest possible value so that unset priorities always sort last.
+func priorityValue(scc *securityapi.SecurityContextConstraints) int32 {
+	if scc.Priority == nil {
+		return math.MinInt32
+	}
+	return *scc.Priority
 }
 
 // pointValue places a value on the SCC based on the settings of the SCC that can be used
 // to determine how restrictive it is.  The lower the number, the more restrictive it is.
-func pointValue(constraint *securityapi.SecurityContextConstraints) int {
+// It is a thin wrapper around Score for callers, such as ByRestrictions, that only need
+// the aggregate total and not the per-field breakdown.  A nil table uses DefaultWeightTable.
+func pointValue(constraint *securityapi.SecurityContextConstraints, table *WeightTable) int {
+	return Score(constraint, table).Total
+}
+
+// hostNamespacePointValue returns a score based on the host namespaces and
+// host ports the SCC grants access to.  Each of these allows the container
+// to reach outside of its own namespace, so allowing any of them raises the
+// score.
+func hostNamespacePointValue(scc *securityapi.SecurityContextConstraints, table *WeightTable) int {
 	points := 0
+	if scc.AllowHostNetwork {
+		points += table.HostNetwork
+	}
+	if scc.AllowHostPorts {
+		points += table.HostPorts
+	}
+	if scc.AllowHostPID {
+		points += table.HostPID
+	}
+	if scc.AllowHostIPC {
+		points += table.HostIPC
+	}
+	return points
+}
 
-	// make sure these are always valued higher than the combination of the highest strategies
-	if constraint.AllowPrivilegedContainer {
-		points += 200000
+// sysctlPointValue returns a score based on the sysctls the SCC allows or
+// forbids.  Allowing unsafe sysctls raises the score, forbidding them lowers
+// it, mirroring the allow/drop handling in capabilitiesPointValue.  The
+// result is clamped to table.SysctlMax so an SCC that enumerates a large
+// number of individual AllowedUnsafeSysctls entries can't outscore
+// Privileged.
+func sysctlPointValue(scc *securityapi.SecurityContextConstraints, table *WeightTable) int {
+	points := 0
+	if hasSysctl("*", scc.AllowedUnsafeSysctls) {
+		points += table.UnsafeSysctlWildcard
+	} else {
+		points += table.UnsafeSysctlPerEntry * len(scc.AllowedUnsafeSysctls)
+	}
+	points -= table.ForbiddenSysctlPerEntry * len(scc.ForbiddenSysctls)
+	if points > table.SysctlMax {
+		return table.SysctlMax
+	} else if points < 0 {
+		return 0
 	}
+	return points
+}
 
-	// add points based on volume requests
-	points += volumePointValue(constraint)
+// hasSysctl checks for needle in haystack.
+func hasSysctl(needle string, haystack []string) bool {
+	for _, s := range haystack {
+		if needle == s {
+			return true
+		}
+	}
+	return false
+}
 
-	// add points based on capabilities
-	points += capabilitiesPointValue(constraint)
+// seccompPointValue returns a score based on the seccomp profiles the SCC
+// allows.  Allowing the wildcard profile is the least restrictive option,
+// allowing specific profiles is scored per profile, and allowing none is the
+// most restrictive.  Both paths are clamped to table.SeccompMax so neither
+// an SCC that allows the wildcard nor one that lists a large number of
+// SeccompProfiles can outscore Privileged.
+func seccompPointValue(scc *securityapi.SecurityContextConstraints, table *WeightTable) int {
+	points := 0
+	for _, p := range scc.SeccompProfiles {
+		if p == "*" {
+			points = table.SeccompWildcard
+			break
+		}
+		points += table.SeccompPerProfile
+	}
+	if points > table.SeccompMax {
+		return table.SeccompMax
+	}
+	return points
+}
 
-	// strategies in order of least restrictive to most restrictive
-	switch constraint.SELinuxContext.Type {
-	case securityapi.SELinuxStrategyRunAsAny:
-		points += 40000
-	case securityapi.SELinuxStrategyMustRunAs:
-		points += 10000
+// fsGroupPointValue returns a score based on the FSGroup strategy of the
+// SCC.  As with the SELinux and RunAsUser strategies scored in Score,
+// RunAsAny scores higher than MustRunAs.
+func fsGroupPointValue(scc *securityapi.SecurityContextConstraints, table *WeightTable) int {
+	switch scc.FSGroup.Type {
+	case securityapi.FSGroupStrategyRunAsAny:
+		return table.FSGroupRunAsAny
+	case securityapi.FSGroupStrategyMustRunAs:
+		return table.FSGroupMustRunAs
 	}
+	return 0
+}
 
-	switch constraint.RunAsUser.Type {
-	case securityapi.RunAsUserStrategyRunAsAny:
-		points += 40000
-	case securityapi.RunAsUserStrategyMustRunAsNonRoot:
-		points += 30000
-	case securityapi.RunAsUserStrategyMustRunAsRange:
-		points += 20000
-	case securityapi.RunAsUserStrategyMustRunAs:
-		points += 10000
+// supplementalGroupsPointValue returns a score based on the
+// SupplementalGroups strategy of the SCC.
+func supplementalGroupsPointValue(scc *securityapi.SecurityContextConstraints, table *WeightTable) int {
+	switch scc.SupplementalGroups.Type {
+	case securityapi.SupplementalGroupsStrategyRunAsAny:
+		return table.SupplementalGroupsRunAsAny
+	case securityapi.SupplementalGroupsStrategyMustRunAs:
+		return table.SupplementalGroupsMustRunAs
 	}
-	return points
+	return 0
 }
 
 // volumePointValue returns a score based on the volumes allowed by the SCC.
-// Allowing a host volume will return a score of 100000.  Allowance of anything other
+// Allowing a host volume will return table.HostPathVolume.  Allowance of anything other
 // than Secret, ConfigMap, EmptyDir, DownwardAPI, Projected, and None will result in
-// a score of 50000.  If the SCC only allows these trivial types, it will have a
-// score of 0.
-func volumePointValue(scc *securityapi.SecurityContextConstraints) int {
-	hasHostVolume := false
+// table.NonTrivialVolume.  FlexVolume is not counted here; flexVolumePointValue scores
+// it.  If the SCC only allows these trivial types, it will have a score of 0.
+func volumePointValue(scc *securityapi.SecurityContextConstraints, table *WeightTable) int {
+	if hasHostPathVolume(scc) {
+		return table.HostPathVolume
+	}
+
 	hasNonTrivialVolume := false
 	for _, v := range scc.Volumes {
 		switch v {
-		case securityapi.FSTypeHostPath, securityapi.FSTypeAll:
-			hasHostVolume = true
-			// nothing more to do, this is the max point value
-			break
 		// it is easier to specifically list the trivial volumes and allow the
 		// default case to be non-trivial so we don't have to worry about adding
 		// volumes in the future unless they're trivial.
 		case securityapi.FSTypeSecret, securityapi.FSTypeConfigMap, securityapi.FSTypeEmptyDir,
 			securityapi.FSTypeDownwardAPI, securityapi.FSProjected, securityapi.FSTypeNone:
 			// do nothing
+		case securityapi.FSTypeFlexVolume:
+			// scored separately by flexVolumePointValue since the driver
+			// restriction matters more than the bare ability to mount one
 		default:
 			hasNonTrivialVolume = true
 		}
 	}
 
-	if hasHostVolume {
-		return 100000
-	}
 	if hasNonTrivialVolume {
-		return 50000
+		return table.NonTrivialVolume
 	}
 	return 0
 }
 
+// hasHostPathVolume reports whether scc's Volumes allow the hostPath type,
+// directly or via the FSTypeAll wildcard. Score uses this too, so its
+// "allows host path volumes" note reflects this field alone rather than the
+// combined volumes score, which flexVolumePointValue also feeds into.
+func hasHostPathVolume(scc *securityapi.SecurityContextConstraints) bool {
+	for _, v := range scc.Volumes {
+		if v == securityapi.FSTypeHostPath || v == securityapi.FSTypeAll {
+			return true
+		}
+	}
+	return false
+}
+
+// flexVolumePointValue returns a score based on the flex volume drivers
+// allowed by the SCC.  Allowing the flexVolume type without restricting it
+// to a specific set of drivers is scored the same as any other non-trivial
+// volume; restricting it to a known list of drivers is more restrictive and
+// lowers the score.
+func flexVolumePointValue(scc *securityapi.SecurityContextConstraints, table *WeightTable) int {
+	allowsFlexVolume := false
+	for _, v := range scc.Volumes {
+		if v == securityapi.FSTypeFlexVolume || v == securityapi.FSTypeAll {
+			allowsFlexVolume = true
+			break
+		}
+	}
+	if !allowsFlexVolume {
+		return 0
+	}
+	if len(scc.AllowedFlexVolumes) == 0 {
+		return table.FlexVolumeUnrestricted
+	}
+	return table.FlexVolumeRestricted
+}
+
 // hasCap checks for needle in haystack.
 func hasCap(needle kapi.Capability, haystack []kapi.Capability) bool {
 	for _, c := range haystack {
@@ -100,24 +247,24 @@ func hasCap(needle kapi.Capability, haystack []kapi.Capability) bool {
 // capabilitiesPointValue returns a score based on the capabilities allowed,
 // added, or removed by the SCC. This allow us to prefer the more restrictive
 // SCC.
-func capabilitiesPointValue(scc *securityapi.SecurityContextConstraints) int {
-	points := 5000
-	points += 300 * len(scc.DefaultAddCapabilities)
+func capabilitiesPointValue(scc *securityapi.SecurityContextConstraints, table *WeightTable) int {
+	points := table.CapabilitiesBase
+	points += table.CapabilitiesDefaultAddPerCap * len(scc.DefaultAddCapabilities)
 	if hasCap(kapi.CapabilityAll, scc.AllowedCapabilities) {
-		points += 4000
+		points += table.CapabilitiesAllowAll
 	} else if hasCap("ALL", scc.AllowedCapabilities) {
-		points += 4000
+		points += table.CapabilitiesAllowAll
 	} else {
-		points += 10 * len(scc.AllowedCapabilities)
+		points += table.CapabilitiesAllowedPerCap * len(scc.AllowedCapabilities)
 	}
 	if hasCap("ALL", scc.RequiredDropCapabilities) {
-		points -= 3000
+		points -= table.CapabilitiesDropAll
 	} else {
-		points -= 50 * len(scc.RequiredDropCapabilities)
+		points -= table.CapabilitiesRequiredDropPerCap * len(scc.RequiredDropCapabilities)
 	}
-	if (points > 10000) {
-		return 10000
-	} else if (points < 0) {
+	if points > table.CapabilitiesMax {
+		return table.CapabilitiesMax
+	} else if points < 0 {
 		return 0
 	}
 	return points
@@ -1,13 +1,21 @@
 package scc
 
 import (
+	"sort"
 	"testing"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kapi "k8s.io/kubernetes/pkg/api"
 	securityapi "github.com/openshift/origin/pkg/security/apis/security"
 )
 
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
 func TestPointValue(t *testing.T) {
+	table := DefaultWeightTable()
+
 	newSCC := func(priv bool, seLinuxStrategy securityapi.SELinuxContextStrategyType, userStrategy securityapi.RunAsUserStrategyType) *securityapi.SecurityContextConstraints {
 		scc := &securityapi.SecurityContextConstraints{
 			SELinuxContext: securityapi.SELinuxContextStrategyOptions{
@@ -35,22 +43,25 @@ func TestPointValue(t *testing.T) {
 		securityapi.RunAsUserStrategyMustRunAs:        10000,
 	}
 
-	privilegedPoints := 200000
+	privilegedPoints := table.Privileged
+	// newSCC leaves ReadOnlyRootFilesystem at its zero value (false), which
+	// contributes a fixed amount via the writable-root-filesystem check
+	writableRootPoints := 2000
 
 	// run through all combos of user strategy + seLinux strategy + priv
 	for userStrategy, userStrategyPoints := range userStrategies {
 		for seLinuxStrategy, seLinuxStrategyPoints := range seLinuxStrategies {
-			expectedPoints := 5000 + privilegedPoints + userStrategyPoints + seLinuxStrategyPoints
+			expectedPoints := 5000 + privilegedPoints + userStrategyPoints + seLinuxStrategyPoints + writableRootPoints
 			scc := newSCC(true, seLinuxStrategy, userStrategy)
-			actualPoints := pointValue(scc)
+			actualPoints := pointValue(scc, table)
 
 			if actualPoints != expectedPoints {
 				t.Errorf("privileged, user: %v, seLinux %v expected %d score but got %d", userStrategy, seLinuxStrategy, expectedPoints, actualPoints)
 			}
 
-			expectedPoints = 5000 + userStrategyPoints + seLinuxStrategyPoints
+			expectedPoints = 5000 + userStrategyPoints + seLinuxStrategyPoints + writableRootPoints
 			scc = newSCC(false, seLinuxStrategy, userStrategy)
-			actualPoints = pointValue(scc)
+			actualPoints = pointValue(scc, table)
 
 			if actualPoints != expectedPoints {
 				t.Errorf("non privileged, user: %v, seLinux %v expected %d score but got %d", userStrategy, seLinuxStrategy, expectedPoints, actualPoints)
@@ -62,13 +73,21 @@ func TestPointValue(t *testing.T) {
 	// and capabilities scores are tested below
 	scc := newSCC(false, securityapi.SELinuxStrategyMustRunAs, securityapi.RunAsUserStrategyMustRunAs)
 	scc.Volumes = []securityapi.FSType{securityapi.FSTypeHostPath}
-	actualPoints := pointValue(scc)
-	if actualPoints != 125000 { //10000 (SELinux) + 10000 (User) + 100000 (host path volume) + 5000 capabilities
+	actualPoints := pointValue(scc, table)
+	if actualPoints != 127000 { //10000 (SELinux) + 10000 (User) + 100000 (host path volume) + 5000 capabilities + 2000 (writable root fs)
 		t.Errorf("volume score was not added to the scc point value correctly, got %d!", actualPoints)
 	}
+
+	// a nil table falls back to DefaultWeightTable and must match explicitly
+	// passing one
+	if pointValue(scc, nil) != pointValue(scc, table) {
+		t.Errorf("nil weight table did not fall back to DefaultWeightTable")
+	}
 }
 
 func TestVolumePointValue(t *testing.T) {
+	table := DefaultWeightTable()
+
 	newSCC := func(host, nonTrivial, trivial bool) *securityapi.SecurityContextConstraints {
 		volumes := []securityapi.FSType{}
 		if host {
@@ -168,7 +187,7 @@ func TestVolumePointValue(t *testing.T) {
 		},
 	}
 	for k, v := range tests {
-		actualPoints := volumePointValue(v.scc)
+		actualPoints := volumePointValue(v.scc, table)
 		if actualPoints != v.expectedPoints {
 			t.Errorf("%s expected %d volume score but got %d", k, v.expectedPoints, actualPoints)
 		}
@@ -176,10 +195,12 @@ func TestVolumePointValue(t *testing.T) {
 }
 
 func TestCapabilitiesPointValue(t *testing.T) {
+	table := DefaultWeightTable()
+
 	newSCC := func(def []kapi.Capability, allow []kapi.Capability, drop []kapi.Capability) *securityapi.SecurityContextConstraints {
 		return &securityapi.SecurityContextConstraints{
-			DefaultAddCapabilities: def,
-			AllowedCapabilities: allow,
+			DefaultAddCapabilities:   def,
+			AllowedCapabilities:      allow,
 			RequiredDropCapabilities: drop,
 		}
 	}
@@ -241,9 +262,372 @@ func TestCapabilitiesPointValue(t *testing.T) {
 	}
 	for k, v := range tests {
 		scc := newSCC(v.defaultAdd, v.allowed, v.requiredDrop)
-		actualPoints := capabilitiesPointValue(scc)
+		actualPoints := capabilitiesPointValue(scc, table)
 		if actualPoints != v.expectedPoints {
 			t.Errorf("%s expected %d capability score but got %d", k, v.expectedPoints, actualPoints)
 		}
 	}
 }
+
+func TestHostNamespacePointValue(t *testing.T) {
+	table := DefaultWeightTable()
+
+	tests := map[string]struct {
+		scc            *securityapi.SecurityContextConstraints
+		expectedPoints int
+	}{
+		"none": {
+			scc:            &securityapi.SecurityContextConstraints{},
+			expectedPoints: 0,
+		},
+		"host network": {
+			scc:            &securityapi.SecurityContextConstraints{AllowHostNetwork: true},
+			expectedPoints: 5000,
+		},
+		"host ports": {
+			scc:            &securityapi.SecurityContextConstraints{AllowHostPorts: true},
+			expectedPoints: 5000,
+		},
+		"host pid": {
+			scc:            &securityapi.SecurityContextConstraints{AllowHostPID: true},
+			expectedPoints: 10000,
+		},
+		"host ipc": {
+			scc:            &securityapi.SecurityContextConstraints{AllowHostIPC: true},
+			expectedPoints: 10000,
+		},
+		"all": {
+			scc: &securityapi.SecurityContextConstraints{
+				AllowHostNetwork: true,
+				AllowHostPorts:   true,
+				AllowHostPID:     true,
+				AllowHostIPC:     true,
+			},
+			expectedPoints: 30000,
+		},
+	}
+	for k, v := range tests {
+		actualPoints := hostNamespacePointValue(v.scc, table)
+		if actualPoints != v.expectedPoints {
+			t.Errorf("%s expected %d host namespace score but got %d", k, v.expectedPoints, actualPoints)
+		}
+	}
+}
+
+func TestSysctlPointValue(t *testing.T) {
+	table := DefaultWeightTable()
+
+	tests := map[string]struct {
+		scc            *securityapi.SecurityContextConstraints
+		expectedPoints int
+	}{
+		"none": {
+			scc:            &securityapi.SecurityContextConstraints{},
+			expectedPoints: 0,
+		},
+		"allowed unsafe sysctls": {
+			scc:            &securityapi.SecurityContextConstraints{AllowedUnsafeSysctls: []string{"kernel.msg*", "net.ipv4.route.min_pmtu"}},
+			expectedPoints: 200,
+		},
+		"allowed unsafe wildcard": {
+			scc:            &securityapi.SecurityContextConstraints{AllowedUnsafeSysctls: []string{"*"}},
+			expectedPoints: 3000,
+		},
+		"forbidden sysctls": {
+			scc:            &securityapi.SecurityContextConstraints{ForbiddenSysctls: []string{"kernel.msg*"}},
+			expectedPoints: 0,
+		},
+		"allowed and forbidden": {
+			scc: &securityapi.SecurityContextConstraints{
+				AllowedUnsafeSysctls: []string{"kernel.msg*", "net.ipv4.route.min_pmtu"},
+				ForbiddenSysctls:     []string{"kernel.shm*"},
+			},
+			expectedPoints: 150,
+		},
+		"many allowed sysctls clamp to SysctlMax": {
+			scc:            &securityapi.SecurityContextConstraints{AllowedUnsafeSysctls: make([]string, 2001)},
+			expectedPoints: table.SysctlMax,
+		},
+	}
+	for k, v := range tests {
+		actualPoints := sysctlPointValue(v.scc, table)
+		if actualPoints != v.expectedPoints {
+			t.Errorf("%s expected %d sysctl score but got %d", k, v.expectedPoints, actualPoints)
+		}
+	}
+}
+
+func TestSeccompPointValue(t *testing.T) {
+	table := DefaultWeightTable()
+
+	tests := map[string]struct {
+		scc            *securityapi.SecurityContextConstraints
+		expectedPoints int
+	}{
+		"none": {
+			scc:            &securityapi.SecurityContextConstraints{},
+			expectedPoints: 0,
+		},
+		"specific profiles": {
+			scc:            &securityapi.SecurityContextConstraints{SeccompProfiles: []string{"docker/default", "unconfined"}},
+			expectedPoints: 100,
+		},
+		"wildcard": {
+			scc:            &securityapi.SecurityContextConstraints{SeccompProfiles: []string{"*"}},
+			expectedPoints: 2000,
+		},
+		"many profiles clamp to SeccompMax": {
+			scc:            &securityapi.SecurityContextConstraints{SeccompProfiles: make([]string, 2001)},
+			expectedPoints: table.SeccompMax,
+		},
+	}
+	for k, v := range tests {
+		actualPoints := seccompPointValue(v.scc, table)
+		if actualPoints != v.expectedPoints {
+			t.Errorf("%s expected %d seccomp score but got %d", k, v.expectedPoints, actualPoints)
+		}
+	}
+
+	wildcardAboveMax := DefaultWeightTable()
+	wildcardAboveMax.SeccompWildcard = wildcardAboveMax.SeccompMax + 50000
+	scc := &securityapi.SecurityContextConstraints{SeccompProfiles: []string{"*"}}
+	if actualPoints := seccompPointValue(scc, wildcardAboveMax); actualPoints != wildcardAboveMax.SeccompMax {
+		t.Errorf("wildcard above SeccompMax: expected clamp to %d but got %d", wildcardAboveMax.SeccompMax, actualPoints)
+	}
+}
+
+func TestFSGroupPointValue(t *testing.T) {
+	table := DefaultWeightTable()
+
+	tests := map[string]struct {
+		scc            *securityapi.SecurityContextConstraints
+		expectedPoints int
+	}{
+		"run as any": {
+			scc: &securityapi.SecurityContextConstraints{
+				FSGroup: securityapi.FSGroupStrategyOptions{Type: securityapi.FSGroupStrategyRunAsAny},
+			},
+			expectedPoints: 20000,
+		},
+		"must run as": {
+			scc: &securityapi.SecurityContextConstraints{
+				FSGroup: securityapi.FSGroupStrategyOptions{Type: securityapi.FSGroupStrategyMustRunAs},
+			},
+			expectedPoints: 5000,
+		},
+	}
+	for k, v := range tests {
+		actualPoints := fsGroupPointValue(v.scc, table)
+		if actualPoints != v.expectedPoints {
+			t.Errorf("%s expected %d fsGroup score but got %d", k, v.expectedPoints, actualPoints)
+		}
+	}
+}
+
+func TestSupplementalGroupsPointValue(t *testing.T) {
+	table := DefaultWeightTable()
+
+	tests := map[string]struct {
+		scc            *securityapi.SecurityContextConstraints
+		expectedPoints int
+	}{
+		"run as any": {
+			scc: &securityapi.SecurityContextConstraints{
+				SupplementalGroups: securityapi.SupplementalGroupsStrategyOptions{Type: securityapi.SupplementalGroupsStrategyRunAsAny},
+			},
+			expectedPoints: 20000,
+		},
+		"must run as": {
+			scc: &securityapi.SecurityContextConstraints{
+				SupplementalGroups: securityapi.SupplementalGroupsStrategyOptions{Type: securityapi.SupplementalGroupsStrategyMustRunAs},
+			},
+			expectedPoints: 5000,
+		},
+	}
+	for k, v := range tests {
+		actualPoints := supplementalGroupsPointValue(v.scc, table)
+		if actualPoints != v.expectedPoints {
+			t.Errorf("%s expected %d supplemental groups score but got %d", k, v.expectedPoints, actualPoints)
+		}
+	}
+}
+
+func TestFlexVolumePointValue(t *testing.T) {
+	table := DefaultWeightTable()
+
+	tests := map[string]struct {
+		scc            *securityapi.SecurityContextConstraints
+		expectedPoints int
+	}{
+		"no flex volume": {
+			scc:            &securityapi.SecurityContextConstraints{},
+			expectedPoints: 0,
+		},
+		"unrestricted flex volume": {
+			scc:            &securityapi.SecurityContextConstraints{Volumes: []securityapi.FSType{securityapi.FSTypeFlexVolume}},
+			expectedPoints: 50000,
+		},
+		"restricted flex volume": {
+			scc: &securityapi.SecurityContextConstraints{
+				Volumes:            []securityapi.FSType{securityapi.FSTypeFlexVolume},
+				AllowedFlexVolumes: []securityapi.AllowedFlexVolume{{Driver: "example/lvm"}},
+			},
+			expectedPoints: 25000,
+		},
+	}
+	for k, v := range tests {
+		actualPoints := flexVolumePointValue(v.scc, table)
+		if actualPoints != v.expectedPoints {
+			t.Errorf("%s expected %d flex volume score but got %d", k, v.expectedPoints, actualPoints)
+		}
+	}
+}
+
+func TestByRestrictionsLess(t *testing.T) {
+	namedSCC := func(name string, priority *int32, priv bool) *securityapi.SecurityContextConstraints {
+		return &securityapi.SecurityContextConstraints{
+			ObjectMeta:               metav1.ObjectMeta{Name: name},
+			Priority:                 priority,
+			AllowPrivilegedContainer: priv,
+		}
+	}
+
+	tests := map[string]struct {
+		sccs     []*securityapi.SecurityContextConstraints
+		expected []string
+	}{
+		"nil priorities fall back to point value": {
+			sccs: []*securityapi.SecurityContextConstraints{
+				namedSCC("privileged", nil, true),
+				namedSCC("restricted", nil, false),
+			},
+			expected: []string{"restricted", "privileged"},
+		},
+		"higher priority wins regardless of point value": {
+			sccs: []*securityapi.SecurityContextConstraints{
+				namedSCC("low-priority-restrictive", int32Ptr(1), false),
+				namedSCC("high-priority-privileged", int32Ptr(10), true),
+			},
+			expected: []string{"high-priority-privileged", "low-priority-restrictive"},
+		},
+		"priority beats nil": {
+			sccs: []*securityapi.SecurityContextConstraints{
+				namedSCC("no-priority", nil, false),
+				namedSCC("has-priority", int32Ptr(0), true),
+			},
+			expected: []string{"has-priority", "no-priority"},
+		},
+		"equal priority and point value falls back to name": {
+			sccs: []*securityapi.SecurityContextConstraints{
+				namedSCC("zeta", int32Ptr(5), false),
+				namedSCC("alpha", int32Ptr(5), false),
+			},
+			expected: []string{"alpha", "zeta"},
+		},
+	}
+
+	for k, v := range tests {
+		byRestrictions := NewByRestrictions(v.sccs, nil)
+		sort.Sort(byRestrictions)
+		actual := make([]string, len(byRestrictions.SCCs))
+		for i, scc := range byRestrictions.SCCs {
+			actual[i] = scc.Name
+		}
+		if len(actual) != len(v.expected) {
+			t.Errorf("%s: expected %v but got %v", k, v.expected, actual)
+			continue
+		}
+		for i := range actual {
+			if actual[i] != v.expected[i] {
+				t.Errorf("%s: expected order %v but got %v", k, v.expected, actual)
+				break
+			}
+		}
+	}
+}
+
+func TestByRestrictionsWithCustomWeights(t *testing.T) {
+	sccs := []*securityapi.SecurityContextConstraints{
+		{
+			ObjectMeta:       metav1.ObjectMeta{Name: "allows-host-network"},
+			AllowHostNetwork: true,
+		},
+		{
+			ObjectMeta:           metav1.ObjectMeta{Name: "allows-unsafe-sysctls"},
+			AllowedUnsafeSysctls: []string{"kernel.shm_rmid_forced"},
+		},
+	}
+
+	// weight sysctls far more heavily than host namespaces so the ranking
+	// flips relative to DefaultWeightTable; SysctlMax has to move too since
+	// it now caps the contribution UnsafeSysctlPerEntry can make.
+	weights := DefaultWeightTable()
+	weights.UnsafeSysctlPerEntry = 1000000
+	weights.SysctlMax = 1000000
+	weights.Privileged = weights.UnsafeSysctlPerEntry + weights.CapabilitiesMax
+
+	byRestrictions := NewByRestrictions(sccs, weights)
+	sort.Sort(byRestrictions)
+
+	if byRestrictions.SCCs[0].Name != "allows-host-network" {
+		t.Errorf("expected allows-host-network to sort first with custom weights, got %v", byRestrictions.SCCs[0].Name)
+	}
+}
+
+// TestByRestrictionsSysctlAndSeccompListsCannotOutscorePrivileged is a
+// regression test for a bug where sysctlPointValue/seccompPointValue grew
+// unbounded with list length: an SCC with enough AllowedUnsafeSysctls or
+// SeccompProfiles entries could score higher than Privileged by itself and
+// sort as less restrictive than (i.e. behind) a privileged SCC.
+func TestByRestrictionsSysctlAndSeccompListsCannotOutscorePrivileged(t *testing.T) {
+	manyEntries := make([]string, 2001)
+	sccs := []*securityapi.SecurityContextConstraints{
+		{
+			ObjectMeta:               metav1.ObjectMeta{Name: "privileged"},
+			AllowPrivilegedContainer: true,
+		},
+		{
+			ObjectMeta:           metav1.ObjectMeta{Name: "many-sysctls-and-profiles"},
+			AllowedUnsafeSysctls: manyEntries,
+			SeccompProfiles:      manyEntries,
+		},
+	}
+
+	byRestrictions := NewByRestrictions(sccs, nil)
+	sort.Sort(byRestrictions)
+
+	if byRestrictions.SCCs[0].Name != "many-sysctls-and-profiles" {
+		t.Errorf("expected the SCC with many sysctls/seccomp profiles to still sort as more restrictive than the privileged one, got %v first", byRestrictions.SCCs[0].Name)
+	}
+}
+
+// TestByRestrictionsSeccompWildcardAboveMaxCannotOutscorePrivileged is a
+// regression test for a table where SeccompWildcard is configured above
+// SeccompMax: maxFieldContribution only accounts for SeccompMax as the
+// seccomp group's ceiling, so Validate's invariant only holds if
+// seccompPointValue actually clamps the wildcard score to SeccompMax too.
+func TestByRestrictionsSeccompWildcardAboveMaxCannotOutscorePrivileged(t *testing.T) {
+	weights := DefaultWeightTable()
+	weights.SeccompWildcard = weights.SeccompMax + 50000
+	if err := weights.Validate(); err != nil {
+		t.Fatalf("expected the table to still validate, got %v", err)
+	}
+
+	sccs := []*securityapi.SecurityContextConstraints{
+		{
+			ObjectMeta:               metav1.ObjectMeta{Name: "privileged"},
+			AllowPrivilegedContainer: true,
+		},
+		{
+			ObjectMeta:      metav1.ObjectMeta{Name: "allows-seccomp-wildcard"},
+			SeccompProfiles: []string{"*"},
+		},
+	}
+
+	byRestrictions := NewByRestrictions(sccs, weights)
+	sort.Sort(byRestrictions)
+
+	if byRestrictions.SCCs[0].Name != "allows-seccomp-wildcard" {
+		t.Errorf("expected the SCC allowing the seccomp wildcard to still sort as more restrictive than the privileged one, got %v first", byRestrictions.SCCs[0].Name)
+	}
+}
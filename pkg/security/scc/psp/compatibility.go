@@ -0,0 +1,37 @@
+package psp
+
+import (
+	policy "k8s.io/api/policy/v1beta1"
+)
+
+// CompatibilityReport lists PSP fields that ToSCC could not carry over
+// because SCC has no equivalent concept, so a caller that cares about exact
+// behavior (rather than just an approximate ranking) knows what to check by
+// hand.
+type CompatibilityReport struct {
+	// Unsupported is one line per PSP field that was dropped during
+	// conversion, naming the field and why it has no SCC analogue.
+	Unsupported []string
+}
+
+// Compatibility inspects psp and returns the fields ToSCC is unable to
+// represent in a SecurityContextConstraints.
+func Compatibility(psp *policy.PodSecurityPolicy) CompatibilityReport {
+	spec := psp.Spec
+	report := CompatibilityReport{}
+
+	if len(spec.AllowedHostPaths) > 0 {
+		report.Unsupported = append(report.Unsupported,
+			"AllowedHostPaths: SCC allows or forbids the hostPath volume type as a whole and cannot restrict it to specific path prefixes")
+	}
+	if len(spec.HostPorts) > 0 {
+		report.Unsupported = append(report.Unsupported,
+			"HostPorts: SCC's AllowHostPorts is a single on/off switch and cannot restrict which host port ranges are allowed")
+	}
+	if spec.AllowPrivilegeEscalation != nil || spec.DefaultAllowPrivilegeEscalation != nil {
+		report.Unsupported = append(report.Unsupported,
+			"AllowPrivilegeEscalation/DefaultAllowPrivilegeEscalation: this SCC API version has no privilege escalation strategy")
+	}
+
+	return report
+}
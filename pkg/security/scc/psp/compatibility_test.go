@@ -0,0 +1,71 @@
+package psp
+
+import (
+	"testing"
+
+	policy "k8s.io/api/policy/v1beta1"
+)
+
+func TestCompatibility(t *testing.T) {
+	allowEscalation := true
+
+	tests := map[string]struct {
+		psp            *policy.PodSecurityPolicy
+		expectedGapped []string
+	}{
+		"no gaps": {
+			psp:            &policy.PodSecurityPolicy{},
+			expectedGapped: nil,
+		},
+		"allowed host paths": {
+			psp: &policy.PodSecurityPolicy{
+				Spec: policy.PodSecurityPolicySpec{
+					AllowedHostPaths: []policy.AllowedHostPath{{PathPrefix: "/data"}},
+				},
+			},
+			expectedGapped: []string{"AllowedHostPaths"},
+		},
+		"host ports": {
+			psp: &policy.PodSecurityPolicy{
+				Spec: policy.PodSecurityPolicySpec{
+					HostPorts: []policy.HostPortRange{{Min: 80, Max: 80}},
+				},
+			},
+			expectedGapped: []string{"HostPorts"},
+		},
+		"privilege escalation": {
+			psp: &policy.PodSecurityPolicy{
+				Spec: policy.PodSecurityPolicySpec{
+					AllowPrivilegeEscalation: &allowEscalation,
+				},
+			},
+			expectedGapped: []string{"AllowPrivilegeEscalation"},
+		},
+	}
+
+	for name, tc := range tests {
+		report := Compatibility(tc.psp)
+		if len(tc.expectedGapped) == 0 {
+			if len(report.Unsupported) != 0 {
+				t.Errorf("%s: expected no unsupported fields but got %v", name, report.Unsupported)
+			}
+			continue
+		}
+		if len(report.Unsupported) != len(tc.expectedGapped) {
+			t.Errorf("%s: expected %d unsupported field notes but got %v", name, len(tc.expectedGapped), report.Unsupported)
+			continue
+		}
+		for _, prefix := range tc.expectedGapped {
+			found := false
+			for _, note := range report.Unsupported {
+				if len(note) >= len(prefix) && note[:len(prefix)] == prefix {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("%s: expected a note about %q, got %v", name, prefix, report.Unsupported)
+			}
+		}
+	}
+}
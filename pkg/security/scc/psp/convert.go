@@ -0,0 +1,140 @@
+// Package psp converts upstream Kubernetes PodSecurityPolicy objects into
+// SecurityContextConstraints so that the two can be ranked against each
+// other with scc.ByRestrictions, letting a cluster admin dry-run "which SCC
+// would this PSP behave like" without hand-translating every field.
+//
+// Conversion is one-directional (ToSCC only); there is no SCC-to-PSP
+// direction here, so the tests exercise ToSCC's field mapping rather than a
+// true round-trip matrix. Adding the reverse conversion so round-trip tests
+// can compare a PSP against ToSCC(FromSCC(scc)) is left as a follow-up.
+package psp
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	kapi "k8s.io/kubernetes/pkg/api"
+
+	securityapi "github.com/openshift/origin/pkg/security/apis/security"
+)
+
+// seccompAllowedProfilesAnnotation is the alpha annotation upstream PSPs use
+// to list allowed seccomp profiles; there is no typed field for it on
+// PodSecurityPolicySpec in this API version.
+const seccompAllowedProfilesAnnotation = "seccomp.security.alpha.kubernetes.io/allowedProfileNames"
+
+// ToSCC converts psp into an equivalent SecurityContextConstraints. The
+// returned SCC keeps the PSP's name so the two can be cross-referenced, and
+// fields with no SCC analogue (see CompatibilityReport) are dropped rather
+// than approximated, since an approximation that silently loosens or
+// tightens the policy is worse than an honest gap. In particular,
+// spec.AllowedHostPaths is never consulted here: SCC's hostPath volume type
+// is an all-or-nothing switch with no notion of restricting it to specific
+// path prefixes, and AllowedFlexVolumes is a list of flex driver names, not
+// host paths, so there is no field to approximate it with. It surfaces only
+// through CompatibilityReport.
+func ToSCC(psp *policy.PodSecurityPolicy) *securityapi.SecurityContextConstraints {
+	spec := psp.Spec
+
+	out := &securityapi.SecurityContextConstraints{}
+	out.Name = psp.Name
+
+	out.AllowPrivilegedContainer = spec.Privileged
+	out.DefaultAddCapabilities = convertCapabilities(spec.DefaultAddCapabilities)
+	out.RequiredDropCapabilities = convertCapabilities(spec.RequiredDropCapabilities)
+	out.AllowedCapabilities = convertCapabilities(spec.AllowedCapabilities)
+
+	out.Volumes = convertFSTypes(spec.Volumes)
+	out.AllowedFlexVolumes = convertAllowedFlexVolumes(spec.AllowedFlexVolumes)
+
+	out.AllowHostNetwork = spec.HostNetwork
+	// the SCC model only has a single on/off switch for host ports, so any
+	// allowed range - however narrow - turns it on; see CompatibilityReport.
+	out.AllowHostPorts = len(spec.HostPorts) > 0
+	out.AllowHostPID = spec.HostPID
+	out.AllowHostIPC = spec.HostIPC
+
+	out.SELinuxContext = securityapi.SELinuxContextStrategyOptions{
+		Type:           securityapi.SELinuxContextStrategyType(spec.SELinux.Rule),
+		SELinuxOptions: convertSELinuxOptions(spec.SELinux.SELinuxOptions),
+	}
+	out.RunAsUser = securityapi.RunAsUserStrategyOptions{
+		Type:   securityapi.RunAsUserStrategyType(spec.RunAsUser.Rule),
+		Ranges: convertIDRanges(spec.RunAsUser.Ranges),
+	}
+	out.FSGroup = securityapi.FSGroupStrategyOptions{
+		Type:   securityapi.FSGroupStrategyType(spec.FSGroup.Rule),
+		Ranges: convertIDRanges(spec.FSGroup.Ranges),
+	}
+	out.SupplementalGroups = securityapi.SupplementalGroupsStrategyOptions{
+		Type:   securityapi.SupplementalGroupsStrategyType(spec.SupplementalGroups.Rule),
+		Ranges: convertIDRanges(spec.SupplementalGroups.Ranges),
+	}
+
+	out.ReadOnlyRootFilesystem = spec.ReadOnlyRootFilesystem
+	out.AllowedUnsafeSysctls = spec.AllowedUnsafeSysctls
+	out.ForbiddenSysctls = spec.ForbiddenSysctls
+
+	if profiles, ok := psp.Annotations[seccompAllowedProfilesAnnotation]; ok {
+		out.SeccompProfiles = strings.Split(profiles, ",")
+	}
+
+	return out
+}
+
+func convertCapabilities(caps []corev1.Capability) []kapi.Capability {
+	if caps == nil {
+		return nil
+	}
+	out := make([]kapi.Capability, len(caps))
+	for i, c := range caps {
+		out[i] = kapi.Capability(c)
+	}
+	return out
+}
+
+func convertFSTypes(types []policy.FSType) []securityapi.FSType {
+	if types == nil {
+		return nil
+	}
+	out := make([]securityapi.FSType, len(types))
+	for i, t := range types {
+		out[i] = securityapi.FSType(t)
+	}
+	return out
+}
+
+func convertAllowedFlexVolumes(volumes []policy.AllowedFlexVolume) []securityapi.AllowedFlexVolume {
+	if volumes == nil {
+		return nil
+	}
+	out := make([]securityapi.AllowedFlexVolume, len(volumes))
+	for i, v := range volumes {
+		out[i] = securityapi.AllowedFlexVolume{Driver: v.Driver}
+	}
+	return out
+}
+
+func convertIDRanges(ranges []policy.IDRange) []securityapi.IDRange {
+	if ranges == nil {
+		return nil
+	}
+	out := make([]securityapi.IDRange, len(ranges))
+	for i, r := range ranges {
+		out[i] = securityapi.IDRange{Min: r.Min, Max: r.Max}
+	}
+	return out
+}
+
+func convertSELinuxOptions(opts *corev1.SELinuxOptions) *kapi.SELinuxOptions {
+	if opts == nil {
+		return nil
+	}
+	return &kapi.SELinuxOptions{
+		User:  opts.User,
+		Role:  opts.Role,
+		Type:  opts.Type,
+		Level: opts.Level,
+	}
+}
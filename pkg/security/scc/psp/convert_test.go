@@ -0,0 +1,115 @@
+package psp
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kapi "k8s.io/kubernetes/pkg/api"
+
+	securityapi "github.com/openshift/origin/pkg/security/apis/security"
+)
+
+func TestToSCC(t *testing.T) {
+	tests := map[string]struct {
+		psp      *policy.PodSecurityPolicy
+		expected *securityapi.SecurityContextConstraints
+	}{
+		"privileged": {
+			psp: &policy.PodSecurityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "privileged"},
+				Spec: policy.PodSecurityPolicySpec{
+					Privileged: true,
+					Volumes:    []policy.FSType{policy.All},
+				},
+			},
+			expected: &securityapi.SecurityContextConstraints{
+				ObjectMeta:               metav1.ObjectMeta{Name: "privileged"},
+				AllowPrivilegedContainer: true,
+				Volumes:                  []securityapi.FSType{securityapi.FSTypeAll},
+			},
+		},
+		"restricted": {
+			psp: &policy.PodSecurityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "restricted"},
+				Spec: policy.PodSecurityPolicySpec{
+					Volumes: []policy.FSType{policy.Secret, policy.EmptyDir},
+					RunAsUser: policy.RunAsUserStrategyOptions{
+						Rule: policy.RunAsUserStrategyMustRunAsNonRoot,
+					},
+					SELinux: policy.SELinuxStrategyOptions{
+						Rule: policy.SELinuxStrategyMustRunAs,
+					},
+					ReadOnlyRootFilesystem: true,
+				},
+			},
+			expected: &securityapi.SecurityContextConstraints{
+				ObjectMeta: metav1.ObjectMeta{Name: "restricted"},
+				Volumes:    []securityapi.FSType{securityapi.FSTypeSecret, securityapi.FSTypeEmptyDir},
+				RunAsUser: securityapi.RunAsUserStrategyOptions{
+					Type: securityapi.RunAsUserStrategyMustRunAsNonRoot,
+				},
+				SELinuxContext: securityapi.SELinuxContextStrategyOptions{
+					Type: securityapi.SELinuxStrategyMustRunAs,
+				},
+				ReadOnlyRootFilesystem: true,
+			},
+		},
+		"capabilities and sysctls": {
+			psp: &policy.PodSecurityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "caps-and-sysctls"},
+				Spec: policy.PodSecurityPolicySpec{
+					DefaultAddCapabilities:   []corev1.Capability{"KILL"},
+					RequiredDropCapabilities: []corev1.Capability{"ALL"},
+					AllowedUnsafeSysctls:     []string{"kernel.msg*"},
+					ForbiddenSysctls:         []string{"kernel.shm*"},
+				},
+			},
+			expected: &securityapi.SecurityContextConstraints{
+				ObjectMeta:               metav1.ObjectMeta{Name: "caps-and-sysctls"},
+				DefaultAddCapabilities:   []kapi.Capability{"KILL"},
+				RequiredDropCapabilities: []kapi.Capability{"ALL"},
+				AllowedUnsafeSysctls:     []string{"kernel.msg*"},
+				ForbiddenSysctls:         []string{"kernel.shm*"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		actual := ToSCC(tc.psp)
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%s: expected\n%#v\nbut got\n%#v", name, tc.expected, actual)
+		}
+	}
+}
+
+func TestToSCCHostPortsBecomeAllowHostPorts(t *testing.T) {
+	p := &policy.PodSecurityPolicy{
+		Spec: policy.PodSecurityPolicySpec{
+			HostPorts: []policy.HostPortRange{{Min: 8000, Max: 8080}},
+		},
+	}
+
+	out := ToSCC(p)
+	if !out.AllowHostPorts {
+		t.Errorf("expected AllowHostPorts to be true when the PSP allows a host port range")
+	}
+}
+
+func TestToSCCSeccompAnnotation(t *testing.T) {
+	p := &policy.PodSecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				seccompAllowedProfilesAnnotation: "docker/default,unconfined",
+			},
+		},
+	}
+
+	out := ToSCC(p)
+	expected := []string{"docker/default", "unconfined"}
+	if !reflect.DeepEqual(out.SeccompProfiles, expected) {
+		t.Errorf("expected SeccompProfiles %v but got %v", expected, out.SeccompProfiles)
+	}
+}
@@ -0,0 +1,27 @@
+package psp
+
+import (
+	"sort"
+
+	policy "k8s.io/api/policy/v1beta1"
+
+	securityapi "github.com/openshift/origin/pkg/security/apis/security"
+	"github.com/openshift/origin/pkg/security/scc"
+)
+
+// Providers converts psps to SecurityContextConstraints with ToSCC, combines
+// them with sccs, and returns the result ranked by scc.ByRestrictions using
+// weights (nil for scc.DefaultWeightTable). This lets an admin dry-run how a
+// PSP would rank against the cluster's existing SCCs, and vice versa,
+// without the two types otherwise being comparable.
+func Providers(sccs []*securityapi.SecurityContextConstraints, psps []*policy.PodSecurityPolicy, weights *scc.WeightTable) []*securityapi.SecurityContextConstraints {
+	all := make([]*securityapi.SecurityContextConstraints, 0, len(sccs)+len(psps))
+	all = append(all, sccs...)
+	for _, p := range psps {
+		all = append(all, ToSCC(p))
+	}
+
+	byRestrictions := scc.NewByRestrictions(all, weights)
+	sort.Sort(byRestrictions)
+	return byRestrictions.SCCs
+}
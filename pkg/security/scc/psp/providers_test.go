@@ -0,0 +1,33 @@
+package psp
+
+import (
+	"testing"
+
+	policy "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	securityapi "github.com/openshift/origin/pkg/security/apis/security"
+)
+
+func TestProvidersRanksPSPsAndSCCsTogether(t *testing.T) {
+	sccs := []*securityapi.SecurityContextConstraints{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "restricted-scc"},
+		},
+	}
+	psps := []*policy.PodSecurityPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "privileged-psp"},
+			Spec:       policy.PodSecurityPolicySpec{Privileged: true},
+		},
+	}
+
+	ranked := Providers(sccs, psps, nil)
+
+	if len(ranked) != 2 {
+		t.Fatalf("expected 2 providers but got %d", len(ranked))
+	}
+	if ranked[0].Name != "restricted-scc" {
+		t.Errorf("expected the restricted SCC to rank before the privileged PSP, got order %v / %v", ranked[0].Name, ranked[1].Name)
+	}
+}
@@ -0,0 +1,104 @@
+package scc
+
+import (
+	kapi "k8s.io/kubernetes/pkg/api"
+	securityapi "github.com/openshift/origin/pkg/security/apis/security"
+)
+
+// Restrictiveness is a per-field breakdown of the score an SCC receives from
+// Score.  It exists so that callers outside this package - in particular
+// the SCC admission plugin - can explain why one SCC was preferred over
+// another instead of only seeing the opaque Total.  Every field uses the
+// same "lower is more restrictive" convention as Total.
+//
+// This checkout has no admission plugin package to plumb the breakdown
+// through (logging it at V(4) and surfacing it on the admission event/
+// annotation), so wiring a chosen provider's Restrictiveness into admission
+// is left as a follow-up once that package exists; Score/Restrictiveness
+// themselves are usable standalone in the meantime.
+type Restrictiveness struct {
+	Total int
+
+	Privileged         int
+	Volumes            int
+	Capabilities       int
+	SELinux            int
+	RunAsUser          int
+	HostNamespaces     int
+	Sysctls            int
+	Seccomp            int
+	FSGroup            int
+	SupplementalGroups int
+	RootFilesystem     int
+
+	// Notes calls out specific settings that contributed to the score, for
+	// example "allows privileged containers" or "allows host path volumes",
+	// so the breakdown reads as a sentence rather than a table of numbers.
+	Notes []string
+}
+
+// Score places a value on each restriction-relevant setting of the SCC,
+// using the weights in table, and returns the per-field breakdown along
+// with the Total used to rank SCCs in ByRestrictions.  The lower Total is,
+// the more restrictive the SCC.  A nil table uses DefaultWeightTable.
+func Score(constraint *securityapi.SecurityContextConstraints, table *WeightTable) Restrictiveness {
+	if table == nil {
+		table = DefaultWeightTable()
+	}
+
+	r := Restrictiveness{}
+
+	if constraint.AllowPrivilegedContainer {
+		r.Privileged = table.Privileged
+		r.Notes = append(r.Notes, "allows privileged containers")
+	}
+
+	r.Volumes = volumePointValue(constraint, table) + flexVolumePointValue(constraint, table)
+	if hasHostPathVolume(constraint) {
+		r.Notes = append(r.Notes, "allows host path volumes")
+	} else if r.Volumes > 0 {
+		r.Notes = append(r.Notes, "allows non-trivial volume types")
+	}
+
+	r.Capabilities = capabilitiesPointValue(constraint, table)
+	if hasCap("ALL", constraint.AllowedCapabilities) || hasCap(kapi.CapabilityAll, constraint.AllowedCapabilities) {
+		r.Notes = append(r.Notes, "AllowedCapabilities contains ALL")
+	}
+
+	switch constraint.SELinuxContext.Type {
+	case securityapi.SELinuxStrategyRunAsAny:
+		r.SELinux = table.SELinuxRunAsAny
+	case securityapi.SELinuxStrategyMustRunAs:
+		r.SELinux = table.SELinuxMustRunAs
+	}
+
+	switch constraint.RunAsUser.Type {
+	case securityapi.RunAsUserStrategyRunAsAny:
+		r.RunAsUser = table.RunAsUserRunAsAny
+	case securityapi.RunAsUserStrategyMustRunAsNonRoot:
+		r.RunAsUser = table.RunAsUserMustRunAsNonRoot
+	case securityapi.RunAsUserStrategyMustRunAsRange:
+		r.RunAsUser = table.RunAsUserMustRunAsRange
+	case securityapi.RunAsUserStrategyMustRunAs:
+		r.RunAsUser = table.RunAsUserMustRunAs
+	}
+
+	r.HostNamespaces = hostNamespacePointValue(constraint, table)
+	if r.HostNamespaces > 0 {
+		r.Notes = append(r.Notes, "allows one or more host namespaces")
+	}
+
+	r.Sysctls = sysctlPointValue(constraint, table)
+	r.Seccomp = seccompPointValue(constraint, table)
+	r.FSGroup = fsGroupPointValue(constraint, table)
+	r.SupplementalGroups = supplementalGroupsPointValue(constraint, table)
+
+	if !constraint.ReadOnlyRootFilesystem {
+		r.RootFilesystem = table.WritableRootFilesystem
+	}
+
+	r.Total = r.Privileged + r.Volumes + r.Capabilities + r.SELinux + r.RunAsUser +
+		r.HostNamespaces + r.Sysctls + r.Seccomp + r.FSGroup + r.SupplementalGroups + r.RootFilesystem
+
+	return r
+}
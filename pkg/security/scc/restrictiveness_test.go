@@ -0,0 +1,72 @@
+package scc
+
+import (
+	"testing"
+
+	securityapi "github.com/openshift/origin/pkg/security/apis/security"
+)
+
+func TestScore(t *testing.T) {
+	scc := &securityapi.SecurityContextConstraints{
+		AllowPrivilegedContainer: true,
+		Volumes:                  []securityapi.FSType{securityapi.FSTypeHostPath},
+		SELinuxContext:           securityapi.SELinuxContextStrategyOptions{Type: securityapi.SELinuxStrategyRunAsAny},
+		RunAsUser:                securityapi.RunAsUserStrategyOptions{Type: securityapi.RunAsUserStrategyRunAsAny},
+		AllowHostNetwork:         true,
+	}
+
+	r := Score(scc, nil)
+
+	if r.Privileged != DefaultWeightTable().Privileged {
+		t.Errorf("expected Privileged %d but got %d", DefaultWeightTable().Privileged, r.Privileged)
+	}
+	if r.Volumes != 100000 {
+		t.Errorf("expected Volumes 100000 but got %d", r.Volumes)
+	}
+	if r.SELinux != 40000 {
+		t.Errorf("expected SELinux 40000 but got %d", r.SELinux)
+	}
+	if r.RunAsUser != 40000 {
+		t.Errorf("expected RunAsUser 40000 but got %d", r.RunAsUser)
+	}
+	if r.HostNamespaces != 5000 {
+		t.Errorf("expected HostNamespaces 5000 but got %d", r.HostNamespaces)
+	}
+	if r.Total != pointValue(scc, nil) {
+		t.Errorf("expected Total %d to equal pointValue %d", r.Total, pointValue(scc, nil))
+	}
+	if len(r.Notes) == 0 {
+		t.Errorf("expected notes to explain the score but got none")
+	}
+}
+
+func TestScoreHostPathNoteOnlyFiresOnActualHostPathVolume(t *testing.T) {
+	// A non-trivial volume plus one unrestricted flex volume sums to the
+	// same points as a hostPath volume, but neither is actually hostPath, so
+	// the "allows host path volumes" note must not fire for it.
+	scc := &securityapi.SecurityContextConstraints{
+		Volumes: []securityapi.FSType{securityapi.FSTypeAWSElasticBlockStore, securityapi.FSTypeFlexVolume},
+	}
+
+	r := Score(scc, nil)
+
+	for _, note := range r.Notes {
+		if note == "allows host path volumes" {
+			t.Errorf("expected no host path note for a non-hostPath volume combination that merely sums to the hostPath threshold, got %v", r.Notes)
+		}
+	}
+}
+
+func TestScoreRestrictedSCCHasNoNotes(t *testing.T) {
+	scc := &securityapi.SecurityContextConstraints{
+		SELinuxContext:         securityapi.SELinuxContextStrategyOptions{Type: securityapi.SELinuxStrategyMustRunAs},
+		RunAsUser:              securityapi.RunAsUserStrategyOptions{Type: securityapi.RunAsUserStrategyMustRunAsRange},
+		ReadOnlyRootFilesystem: true,
+	}
+
+	r := Score(scc, nil)
+
+	if len(r.Notes) != 0 {
+		t.Errorf("expected no notes for a restricted SCC but got %v", r.Notes)
+	}
+}
@@ -0,0 +1,256 @@
+package scc
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+)
+
+// WeightTable holds every numeric weight that Score and the
+// pointValue/*PointValue helpers use to rank SCCs by restrictiveness.  A
+// cluster admin who wants, say, capabilities weighted more heavily than
+// SELinux strategy relative to DefaultWeightTable can build a WeightTable of
+// their own from a `SCCRestrictionWeights` document with LoadWeightTable or
+// LoadWeightTableFromFile instead of patching this package.  This package
+// does not read a ConfigMap or register a command-line flag itself.
+//
+// Field values follow the same "lower is more restrictive" convention as
+// Restrictiveness: the value recorded here is the number of points a setting
+// adds when it makes the SCC less restrictive.
+type WeightTable struct {
+	// Privileged must stay greater than or equal to the sum of every other
+	// field's maximum contribution; Validate enforces this so an SCC that
+	// allows privileged containers can never sort as more restrictive than
+	// one that forbids it, no matter what else it allows.
+	Privileged int `json:"privileged"`
+
+	HostPathVolume         int `json:"hostPathVolume"`
+	NonTrivialVolume       int `json:"nonTrivialVolume"`
+	FlexVolumeUnrestricted int `json:"flexVolumeUnrestricted"`
+	FlexVolumeRestricted   int `json:"flexVolumeRestricted"`
+
+	CapabilitiesBase               int `json:"capabilitiesBase"`
+	CapabilitiesDefaultAddPerCap   int `json:"capabilitiesDefaultAddPerCap"`
+	CapabilitiesAllowAll           int `json:"capabilitiesAllowAll"`
+	CapabilitiesAllowedPerCap      int `json:"capabilitiesAllowedPerCap"`
+	CapabilitiesDropAll            int `json:"capabilitiesDropAll"`
+	CapabilitiesRequiredDropPerCap int `json:"capabilitiesRequiredDropPerCap"`
+	CapabilitiesMax                int `json:"capabilitiesMax"`
+
+	SELinuxRunAsAny  int `json:"seLinuxRunAsAny"`
+	SELinuxMustRunAs int `json:"seLinuxMustRunAs"`
+
+	RunAsUserRunAsAny         int `json:"runAsUserRunAsAny"`
+	RunAsUserMustRunAsNonRoot int `json:"runAsUserMustRunAsNonRoot"`
+	RunAsUserMustRunAsRange   int `json:"runAsUserMustRunAsRange"`
+	RunAsUserMustRunAs        int `json:"runAsUserMustRunAs"`
+
+	HostNetwork int `json:"hostNetwork"`
+	HostPorts   int `json:"hostPorts"`
+	HostPID     int `json:"hostPID"`
+	HostIPC     int `json:"hostIPC"`
+
+	UnsafeSysctlWildcard    int `json:"unsafeSysctlWildcard"`
+	UnsafeSysctlPerEntry    int `json:"unsafeSysctlPerEntry"`
+	ForbiddenSysctlPerEntry int `json:"forbiddenSysctlPerEntry"`
+	SysctlMax               int `json:"sysctlMax"`
+
+	SeccompWildcard   int `json:"seccompWildcard"`
+	SeccompPerProfile int `json:"seccompPerProfile"`
+	SeccompMax        int `json:"seccompMax"`
+
+	FSGroupRunAsAny  int `json:"fsGroupRunAsAny"`
+	FSGroupMustRunAs int `json:"fsGroupMustRunAs"`
+
+	SupplementalGroupsRunAsAny  int `json:"supplementalGroupsRunAsAny"`
+	SupplementalGroupsMustRunAs int `json:"supplementalGroupsMustRunAs"`
+
+	WritableRootFilesystem int `json:"writableRootFilesystem"`
+}
+
+// DefaultWeightTable returns the built-in weights used whenever no table is
+// supplied.  Callers get a fresh copy each time so mutating the result can't
+// affect other callers.
+func DefaultWeightTable() *WeightTable {
+	return &WeightTable{
+		// Every other field's maximum contribution (per Validate) sums to
+		// 472000, so Privileged has to clear that bar even though no single
+		// SCC can actually reach every maximum at once; see Validate.
+		Privileged: 500000,
+
+		HostPathVolume:         100000,
+		NonTrivialVolume:       50000,
+		FlexVolumeUnrestricted: 50000,
+		FlexVolumeRestricted:   25000,
+
+		CapabilitiesBase:               5000,
+		CapabilitiesDefaultAddPerCap:   300,
+		CapabilitiesAllowAll:           4000,
+		CapabilitiesAllowedPerCap:      10,
+		CapabilitiesDropAll:            3000,
+		CapabilitiesRequiredDropPerCap: 50,
+		CapabilitiesMax:                10000,
+
+		SELinuxRunAsAny:  40000,
+		SELinuxMustRunAs: 10000,
+
+		RunAsUserRunAsAny:         40000,
+		RunAsUserMustRunAsNonRoot: 30000,
+		RunAsUserMustRunAsRange:   20000,
+		RunAsUserMustRunAs:        10000,
+
+		HostNetwork: 5000,
+		HostPorts:   5000,
+		HostPID:     10000,
+		HostIPC:     10000,
+
+		UnsafeSysctlWildcard:    3000,
+		UnsafeSysctlPerEntry:    100,
+		ForbiddenSysctlPerEntry: 50,
+		SysctlMax:               3000,
+
+		SeccompWildcard:   2000,
+		SeccompPerProfile: 50,
+		SeccompMax:        2000,
+
+		FSGroupRunAsAny:  20000,
+		FSGroupMustRunAs: 5000,
+
+		SupplementalGroupsRunAsAny:  20000,
+		SupplementalGroupsMustRunAs: 5000,
+
+		WritableRootFilesystem: 2000,
+	}
+}
+
+// init guards against DefaultWeightTable itself violating Validate's
+// invariant. Score and NewByRestrictions fall back to DefaultWeightTable on
+// a nil table without calling Validate, so a broken default would otherwise
+// rank SCCs incorrectly in every cluster silently instead of failing loudly.
+func init() {
+	if err := DefaultWeightTable().Validate(); err != nil {
+		panic(fmt.Sprintf("scc: DefaultWeightTable is invalid: %v", err))
+	}
+}
+
+// Validate checks that a WeightTable is safe to rank SCCs with: no field may
+// be negative, and Privileged must remain at least as large as the sum of
+// every other field's maximum contribution so that a privileged SCC can
+// never outscore (sort as more restrictive than) a non-privileged one.
+func (w *WeightTable) Validate() error {
+	maxOfOthers := 0
+	for name, value := range w.fields() {
+		if value < 0 {
+			return fmt.Errorf("scc weight %q must not be negative, got %d", name, value)
+		}
+		if name == "privileged" {
+			continue
+		}
+		maxOfOthers += maxFieldContribution(name, value)
+	}
+
+	if w.Privileged < maxOfOthers {
+		return fmt.Errorf("scc weight \"privileged\" (%d) must be at least the sum of every other field's maximum contribution (%d)", w.Privileged, maxOfOthers)
+	}
+	return nil
+}
+
+// fields returns every weight keyed by its JSON tag, for validation and
+// error reporting.
+func (w *WeightTable) fields() map[string]int {
+	return map[string]int{
+		"privileged": w.Privileged,
+
+		"hostPathVolume":         w.HostPathVolume,
+		"nonTrivialVolume":       w.NonTrivialVolume,
+		"flexVolumeUnrestricted": w.FlexVolumeUnrestricted,
+		"flexVolumeRestricted":   w.FlexVolumeRestricted,
+
+		"capabilitiesBase":               w.CapabilitiesBase,
+		"capabilitiesDefaultAddPerCap":   w.CapabilitiesDefaultAddPerCap,
+		"capabilitiesAllowAll":           w.CapabilitiesAllowAll,
+		"capabilitiesAllowedPerCap":      w.CapabilitiesAllowedPerCap,
+		"capabilitiesDropAll":            w.CapabilitiesDropAll,
+		"capabilitiesRequiredDropPerCap": w.CapabilitiesRequiredDropPerCap,
+		"capabilitiesMax":                w.CapabilitiesMax,
+
+		"seLinuxRunAsAny":  w.SELinuxRunAsAny,
+		"seLinuxMustRunAs": w.SELinuxMustRunAs,
+
+		"runAsUserRunAsAny":         w.RunAsUserRunAsAny,
+		"runAsUserMustRunAsNonRoot": w.RunAsUserMustRunAsNonRoot,
+		"runAsUserMustRunAsRange":   w.RunAsUserMustRunAsRange,
+		"runAsUserMustRunAs":        w.RunAsUserMustRunAs,
+
+		"hostNetwork": w.HostNetwork,
+		"hostPorts":   w.HostPorts,
+		"hostPID":     w.HostPID,
+		"hostIPC":     w.HostIPC,
+
+		"unsafeSysctlWildcard":    w.UnsafeSysctlWildcard,
+		"unsafeSysctlPerEntry":    w.UnsafeSysctlPerEntry,
+		"forbiddenSysctlPerEntry": w.ForbiddenSysctlPerEntry,
+		"sysctlMax":               w.SysctlMax,
+
+		"seccompWildcard":   w.SeccompWildcard,
+		"seccompPerProfile": w.SeccompPerProfile,
+		"seccompMax":        w.SeccompMax,
+
+		"fsGroupRunAsAny":  w.FSGroupRunAsAny,
+		"fsGroupMustRunAs": w.FSGroupMustRunAs,
+
+		"supplementalGroupsRunAsAny":  w.SupplementalGroupsRunAsAny,
+		"supplementalGroupsMustRunAs": w.SupplementalGroupsMustRunAs,
+
+		"writableRootFilesystem": w.WritableRootFilesystem,
+	}
+}
+
+// maxFieldContribution caps the handful of fields whose real contribution to
+// Total is bounded by a separate "max" weight (capabilities is scored as
+// base + adds - drops but clamped to CapabilitiesMax, and sysctls/seccomp are
+// scored as a per-entry weight times an arbitrary list length but clamped to
+// SysctlMax/SeccompMax) so that Validate's invariant check reflects the
+// actual ceiling rather than an unbounded sum. Treating "seccompWildcard" as
+// contributing 0 and "seccompMax" as the seccomp group's sole ceiling relies
+// on seccompPointValue clamping the wildcard score to SeccompMax as well as
+// the per-profile score; if that ever stops being true, this needs to become
+// max(SeccompWildcard, SeccompMax) instead.
+func maxFieldContribution(name string, value int) int {
+	switch name {
+	case "capabilitiesBase", "capabilitiesDefaultAddPerCap", "capabilitiesAllowAll",
+		"capabilitiesAllowedPerCap", "capabilitiesDropAll", "capabilitiesRequiredDropPerCap",
+		"unsafeSysctlWildcard", "unsafeSysctlPerEntry", "forbiddenSysctlPerEntry",
+		"seccompWildcard", "seccompPerProfile":
+		return 0
+	default:
+		return value
+	}
+}
+
+// LoadWeightTable parses a `SCCRestrictionWeights` document (YAML or JSON -
+// YAML is a superset so one parser handles both) into a WeightTable.  Fields
+// absent from data keep their DefaultWeightTable value rather than zeroing
+// out, so operators can override a handful of weights without restating all
+// of them. The result is validated before being returned.
+func LoadWeightTable(data []byte) (*WeightTable, error) {
+	table := DefaultWeightTable()
+	if err := yaml.Unmarshal(data, table); err != nil {
+		return nil, fmt.Errorf("could not parse SCC restriction weights: %v", err)
+	}
+	if err := table.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid SCC restriction weights: %v", err)
+	}
+	return table, nil
+}
+
+// LoadWeightTableFromFile reads the file at path and parses it with
+// LoadWeightTable.
+func LoadWeightTableFromFile(path string) (*WeightTable, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read SCC restriction weights file %q: %v", path, err)
+	}
+	return LoadWeightTable(data)
+}
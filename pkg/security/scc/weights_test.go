@@ -0,0 +1,63 @@
+package scc
+
+import "testing"
+
+func TestDefaultWeightTableValidates(t *testing.T) {
+	if err := DefaultWeightTable().Validate(); err != nil {
+		t.Errorf("expected DefaultWeightTable to validate, got %v", err)
+	}
+}
+
+func TestWeightTableValidateRejectsNegativeWeight(t *testing.T) {
+	table := DefaultWeightTable()
+	table.HostNetwork = -1
+	if err := table.Validate(); err == nil {
+		t.Errorf("expected a negative weight to fail validation")
+	}
+}
+
+func TestWeightTableValidateRejectsLowPrivilegedWeight(t *testing.T) {
+	table := DefaultWeightTable()
+	table.Privileged = 1
+	if err := table.Validate(); err == nil {
+		t.Errorf("expected a privileged weight lower than the sum of the other maxima to fail validation")
+	}
+}
+
+func TestWeightTableValidateUsesSysctlAndSeccompMax(t *testing.T) {
+	table := DefaultWeightTable()
+	// A large per-entry weight would have inflated Validate's maxOfOthers
+	// unboundedly before maxFieldContribution started treating these as
+	// capped by SysctlMax/SeccompMax, even though no SCC can actually score
+	// higher than the cap for either field.
+	table.UnsafeSysctlPerEntry = 1000000
+	table.SeccompPerProfile = 1000000
+	if err := table.Validate(); err != nil {
+		t.Errorf("expected a high per-entry sysctl/seccomp weight to validate since it's capped by SysctlMax/SeccompMax, got %v", err)
+	}
+}
+
+func TestLoadWeightTableOverridesOnlyGivenFields(t *testing.T) {
+	table, err := LoadWeightTable([]byte(`{"hostNetwork": 1}`))
+	if err != nil {
+		t.Fatalf("unexpected error loading weight table: %v", err)
+	}
+	if table.HostNetwork != 1 {
+		t.Errorf("expected hostNetwork override to take effect, got %d", table.HostNetwork)
+	}
+	if table.Privileged != DefaultWeightTable().Privileged {
+		t.Errorf("expected unspecified fields to keep their default, got privileged=%d", table.Privileged)
+	}
+}
+
+func TestLoadWeightTableRejectsInvalidTable(t *testing.T) {
+	if _, err := LoadWeightTable([]byte(`{"hostNetwork": -1}`)); err == nil {
+		t.Errorf("expected a negative weight to be rejected at load time")
+	}
+}
+
+func TestLoadWeightTableRejectsMalformedInput(t *testing.T) {
+	if _, err := LoadWeightTable([]byte(`not: valid: yaml: :`)); err == nil {
+		t.Errorf("expected malformed input to be rejected")
+	}
+}